@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/emilyxfox/go-mclogs-bot/mclogs"
+	"github.com/emilyxfox/go-mclogs-bot/mclogs/redact"
+	"github.com/emilyxfox/go-mclogs-bot/ratelimit"
+)
+
+// commands holds the application (slash) commands registered on startup.
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "analyse",
+		Description: "Analyse a log without uploading it to mclo.gs",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "url",
+				Description: "URL of a raw log to analyse",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "Log file to analyse",
+			},
+		},
+	},
+	{
+		Name:        "paste",
+		Description: "Upload a log to mclo.gs and show its insights",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "url",
+				Description: "URL of a raw log to upload",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "Log file to upload",
+			},
+		},
+	},
+	{
+		Name:        "insights",
+		Description: "Show insights for an existing mclo.gs paste",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "id",
+				Description: "The mclo.gs paste ID",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "raw",
+		Description: "Fetch the raw log for an existing mclo.gs paste",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "id",
+				Description: "The mclo.gs paste ID",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "analyse-local",
+		Description: "Analyse a log locally, without contacting mclo.gs",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "url",
+				Description: "URL of a raw log to analyse",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "Log file to analyse",
+			},
+		},
+	},
+}
+
+// commandHandlers dispatches an interaction to the function that implements it.
+var commandHandlers = map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
+	"analyse":       handleAnalyseCommand,
+	"paste":         handlePasteCommand,
+	"insights":      handleInsightsCommand,
+	"raw":           handleRawCommand,
+	"analyse-local": handleAnalyseLocalCommand,
+}
+
+// handleInteractionCreate routes application command interactions to their handler.
+func handleInteractionCreate(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	h, ok := commandHandlers[i.ApplicationCommandData().Name]
+	if !ok {
+		return
+	}
+	h(ctx, s, i)
+}
+
+// errNoLogProvided is returned when neither a url nor a file option was given.
+var errNoLogProvided = errors.New("no url or file option provided")
+
+// optionContent resolves the "url" or "file" option of a command into the
+// raw log content, preferring an uploaded file when both are present.
+// maxSize bounds the download, see maxLogSize and maxLocalLogSize.
+func optionContent(ctx context.Context, i *discordgo.InteractionCreate, maxSize int64) (string, error) {
+	data := i.ApplicationCommandData()
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(data.Options))
+	for _, opt := range data.Options {
+		options[opt.Name] = opt
+	}
+
+	if opt, ok := options["file"]; ok {
+		at, ok := data.Resolved.Attachments[opt.Value.(string)]
+		if !ok {
+			return "", errors.New("attachment not resolved")
+		}
+		return fetchLogContent(ctx, at.URL, maxSize)
+	}
+
+	if opt, ok := options["url"]; ok {
+		return fetchLogContent(ctx, opt.StringValue(), maxSize)
+	}
+
+	return "", errNoLogProvided
+}
+
+// deferResponse acknowledges the interaction immediately so the handler has
+// time to talk to mclo.gs before Discord's 3 second deadline expires.
+func deferResponse(s *discordgo.Session, i *discordgo.InteractionCreate, logger *slog.Logger) bool {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		logger.Error("Error deferring interaction response", "error", err)
+		return false
+	}
+	return true
+}
+
+// respondError edits a deferred response to report content as an error message.
+func respondError(s *discordgo.Session, i *discordgo.InteractionCreate, logger *slog.Logger, content string) {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+func handleAnalyseCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	logger := slog.With(slog.String("command", "analyse"))
+	if !deferResponse(s, i, logger) {
+		return
+	}
+
+	content, err := optionContent(ctx, i, maxLogSize)
+	if err != nil {
+		respondError(s, i, logger, fmt.Sprintf("Couldn't read that log: %v", err))
+		return
+	}
+
+	an, err := mclc.AnalyseLog(ctx, content)
+	if err != nil {
+		logger.Error("Failed to analyse log", "error", err)
+		respondError(s, i, logger, fmt.Sprintf("Failed to analyse log: %v", err))
+		return
+	}
+
+	embeds := logsEmbeds(s, logger, analysedEmbedTitle, insightFields(an, "", nil))
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+func handleAnalyseLocalCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	logger := slog.With(slog.String("command", "analyse-local"))
+	if !deferResponse(s, i, logger) {
+		return
+	}
+
+	content, err := optionContent(ctx, i, maxLocalLogSize)
+	if err != nil {
+		respondError(s, i, logger, fmt.Sprintf("Couldn't read that log: %v", err))
+		return
+	}
+
+	an := localAnalyzer.Analyse(content)
+
+	embeds := logsEmbeds(s, logger, analysedEmbedTitle, insightFields(an, "", nil))
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+func handlePasteCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	logger := slog.With(slog.String("command", "paste"))
+	if !deferResponse(s, i, logger) {
+		return
+	}
+
+	if ok, retryAfter := rl.Allow(ratelimit.Key(i.GuildID, i.ChannelID)); !ok {
+		respondError(s, i, logger, fmt.Sprintf("Rate limited, try again in %s.", retryAfter.Round(time.Second)))
+		return
+	}
+
+	content, err := optionContent(ctx, i, maxLogSize)
+	if err != nil {
+		respondError(s, i, logger, fmt.Sprintf("Couldn't read that log: %v", err))
+		return
+	}
+
+	redacted, redactions := redact.Redact(content, redactMode)
+
+	pr, err := mclc.PasteLog(ctx, redacted)
+	if err != nil {
+		logger.Error("Failed to paste log", "error", err)
+		respondError(s, i, logger, fmt.Sprintf("Failed to upload log: %v", err))
+		return
+	}
+
+	an, err := mclc.GetInsights(ctx, pr.ID)
+	if err != nil {
+		logger.Error("Failed to get paste insights", "error", err, "id", pr.ID)
+		respondError(s, i, logger, fmt.Sprintf("Uploaded as %s, but failed to fetch insights: %v", pr.URL, err))
+		return
+	}
+
+	embeds := logsEmbeds(s, logger, uploadedEmbedTitle, insightFields(an, pr.URL, redactions))
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+func handleInsightsCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	logger := slog.With(slog.String("command", "insights"))
+	if !deferResponse(s, i, logger) {
+		return
+	}
+
+	id := i.ApplicationCommandData().Options[0].StringValue()
+	an, err := mclc.GetInsights(ctx, id)
+	if err != nil {
+		logger.Error("Failed to get paste insights", "error", err, "id", id)
+		respondError(s, i, logger, fmt.Sprintf("Failed to fetch insights for `%s`: %v", id, err))
+		return
+	}
+
+	embeds := logsEmbeds(s, logger, uploadedEmbedTitle, insightFields(an, mclogs.PasteURL(id), nil))
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+func handleRawCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	logger := slog.With(slog.String("command", "raw"))
+	if !deferResponse(s, i, logger) {
+		return
+	}
+
+	id := i.ApplicationCommandData().Options[0].StringValue()
+	raw, err := mclc.GetRawLog(ctx, id)
+	if err != nil {
+		logger.Error("Failed to get raw log", "error", err, "id", id)
+		respondError(s, i, logger, fmt.Sprintf("Failed to fetch raw log for `%s`: %v", id, err))
+		return
+	}
+
+	content := trimRawLog(raw)
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+// rawLogLimit leaves room for the surrounding code block fences within
+// Discord's 2000 character message content limit.
+const rawLogLimit = 1900
+
+// trimRawLog truncates raw to fit within a Discord message and wraps it in a
+// code block.
+func trimRawLog(raw string) string {
+	trimmed := raw
+	suffix := ""
+	if len(trimmed) > rawLogLimit {
+		trimmed = trimmed[len(trimmed)-rawLogLimit:]
+		suffix = "\n... (truncated, showing the end of the log)"
+	}
+	return "```\n" + trimmed + "\n```" + suffix
+}
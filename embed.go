@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/emilyxfox/go-mclogs-bot/mclogs"
+)
+
+// Embed titles for logsEmbeds, depending on whether the log was actually
+// pasted to mclo.gs or only analysed in place.
+const (
+	uploadedEmbedTitle = "Your logs were uploaded for easier reading"
+	analysedEmbedTitle = "Your logs were analysed (not uploaded)"
+)
+
+// maxProblemsListed caps how many distinct problems are listed per log in
+// the embed, so a log with dozens of errors doesn't blow out the field.
+const maxProblemsListed = 5
+
+// curatedInfoLabels are the Analysis.Information labels worth surfacing as a
+// compact summary, in display order.
+var curatedInfoLabels = []string{"Minecraft Version", "Java Version", "Loader"}
+
+// embedFieldValueLimit is Discord's per-field value length limit.
+const embedFieldValueLimit = 1024
+
+// insightFields builds the embed fields describing a single analysed/pasted
+// log. url should be the public mclo.gs URL, or "" if the log was analysed
+// without being uploaded. redactions is the per-label count returned by
+// redact.Redact, or nil if nothing was redacted.
+func insightFields(an *mclogs.InsightsResponse, url string, redactions map[string]int) []*discordgo.MessageEmbedField {
+	value := url
+	if value == "" {
+		value = "*Not uploaded*"
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   an.Title,
+			Value:  value,
+			Inline: true,
+		},
+	}
+
+	if note := redactionNote(redactions); note != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Redacted",
+			Value:  note,
+			Inline: true,
+		})
+	}
+
+	if summary := infoSummary(an.Analysis.Information); summary != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Details",
+			Value:  summary,
+			Inline: true,
+		})
+	}
+
+	if problems := problemsSummary(an.Analysis.Problems); problems != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Problems found",
+			Value:  problems,
+			Inline: false,
+		})
+	}
+
+	return fields
+}
+
+// infoSummary renders a compact, ordered summary of the curated
+// Analysis.Information entries, e.g. "**Minecraft Version:** 1.20.4".
+func infoSummary(info []mclogs.Information) string {
+	byLabel := make(map[string]string, len(curatedInfoLabels))
+	for _, inf := range info {
+		byLabel[inf.Label] = inf.Value
+	}
+
+	var lines []string
+	for _, label := range curatedInfoLabels {
+		if v, ok := byLabel[label]; ok {
+			lines = append(lines, fmt.Sprintf("**%s:** %s", label, v))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// redactionNote renders a transparency note like "Redacted 3 IPs, 1 user
+// path" from the counts returned by redact.Redact.
+func redactionNote(counts map[string]int) string {
+	if len(counts) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0, len(counts))
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		n := counts[label]
+		if n != 1 {
+			label += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, label))
+	}
+
+	return "Redacted " + strings.Join(parts, ", ")
+}
+
+// problemsSummary groups problems by their Message, then lists the first
+// maxProblemsListed groups, in order of first appearance, alongside their
+// first solution.
+func problemsSummary(problems []mclogs.Problem) string {
+	type group struct {
+		message  string
+		counter  int
+		solution string
+	}
+
+	var order []string
+	byMessage := make(map[string]*group)
+	for _, p := range problems {
+		g, ok := byMessage[p.Message]
+		if !ok {
+			g = &group{message: p.Message}
+			if len(p.Solutions) > 0 {
+				g.solution = p.Solutions[0].Message
+			}
+			byMessage[p.Message] = g
+			order = append(order, p.Message)
+		}
+		g.counter += p.Counter
+	}
+
+	if len(order) > maxProblemsListed {
+		order = order[:maxProblemsListed]
+	}
+
+	var b strings.Builder
+	for _, msg := range order {
+		g := byMessage[msg]
+		fmt.Fprintf(&b, "**%s**", g.message)
+		if g.counter > 1 {
+			fmt.Fprintf(&b, " (x%d)", g.counter)
+		}
+		if g.solution != "" {
+			fmt.Fprintf(&b, "\n-# %s", g.solution)
+		}
+		b.WriteString("\n")
+	}
+
+	return truncate(strings.TrimRight(b.String(), "\n"), embedFieldValueLimit)
+}
+
+// truncate shortens s to at most n characters, marking the cut with an
+// ellipsis so it's clear the field was trimmed.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
+// embedFieldLimit and embedCharLimit mirror Discord's per-embed field count
+// and total character limits.
+const (
+	embedFieldLimit = 25
+	embedCharLimit  = 6000
+)
+
+// logsEmbeds assembles the standard mclo.gs embed(s) from a set of fields
+// produced by insightFields, splitting into follow-up embeds if the fields
+// would exceed Discord's per-embed field count or character limits. title is
+// used as-is for the first embed and suffixed with "(continued)" for the
+// rest.
+func logsEmbeds(s *discordgo.Session, logger *slog.Logger, title string, fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbed {
+	botUser, err := s.User("@me")
+	if err != nil {
+		logger.Error("Error fetching bot user", "error", err)
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	var chunk []*discordgo.MessageEmbedField
+	chunkLen := 0
+
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		embedTitle := title
+		if len(embeds) > 0 {
+			embedTitle = title + " (continued)"
+		}
+		e := &discordgo.MessageEmbed{
+			Author: &discordgo.MessageEmbedAuthor{
+				Name: "mclo.gs",
+				URL:  "https://mclo.gs/",
+			},
+			Title:     embedTitle,
+			Fields:    chunk,
+			Color:     0x2d3943,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		if len(embeds) == 0 {
+			e.Description = "-# [Why?](https://github.com/EmilyxFox/go-mclogs-bot/blob/main/why.md) [Source](https://github.com/emilyxfox/go-mclogs-bot)"
+			if botUser != nil {
+				e.Author.IconURL = botUser.AvatarURL("32")
+			}
+		}
+		embeds = append(embeds, e)
+		chunk = nil
+		chunkLen = 0
+	}
+
+	for _, f := range fields {
+		fLen := len(f.Name) + len(f.Value)
+		if len(chunk) >= embedFieldLimit || chunkLen+fLen > embedCharLimit {
+			flush()
+		}
+		chunk = append(chunk, f)
+		chunkLen += fLen
+	}
+	flush()
+
+	return embeds
+}
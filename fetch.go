@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// maxLogSize is the largest attachment we'll download before giving up,
+// matching mclo.gs's own upload cap.
+const maxLogSize = 10 * 1024 * 1024 // 10MiB
+
+// maxLocalLogSize is the largest attachment the local analyzer will accept.
+// It's well above mclo.gs's upload cap, since the local analyzer exists
+// specifically to handle logs too large for mclo.gs to store.
+const maxLocalLogSize = 100 * 1024 * 1024 // 100MiB
+
+// errLogTooLarge is returned by fetchLogContent when the downloaded body
+// exceeds maxSize.
+var errLogTooLarge = errors.New("log content exceeds maximum size")
+
+// fetchLogContent downloads the content at url, aborting the read (rather
+// than buffering it all first) if it exceeds maxSize.
+func fetchLogContent(ctx context.Context, url string, maxSize int64) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, resp.Body, maxSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return "", errLogTooLarge
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -1,24 +1,59 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
-	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/emilyxfox/go-mclogs-bot/mclogs"
+	"github.com/emilyxfox/go-mclogs-bot/mclogs/local"
+	"github.com/emilyxfox/go-mclogs-bot/mclogs/redact"
+	"github.com/emilyxfox/go-mclogs-bot/ratelimit"
 )
 
 var mclc = mclogs.NewClient()
 
-func handleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+var redactMode = redact.ParseMode(os.Getenv("MCLOGS_REDACT"))
+
+var localAnalyzer = mustNewLocalAnalyzer()
+
+// mustNewLocalAnalyzer loads the local analyzer's rule packs. A failure here
+// means a rule pack (embedded or user-supplied) is malformed, which is a
+// startup-time configuration error.
+func mustNewLocalAnalyzer() *local.Analyzer {
+	a, err := local.New(os.Getenv("MCLOGS_RULES_DIR"))
+	if err != nil {
+		slog.Error("Error loading local analyzer rules", "error", err)
+		os.Exit(1)
+	}
+	return a
+}
+
+var rl = ratelimit.NewLimiter(envFloat("RATELIMIT_CHANNEL_RPS", 1), envFloat("RATELIMIT_GLOBAL_RPS", 5))
+
+// envFloat reads key as a float64, falling back to def if it's unset or invalid.
+func envFloat(key string, def float64) float64 {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		slog.Warn("Invalid rate limit env var, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+	return f
+}
+
+func handleMessageCreate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) {
 	if len(m.Attachments) < 1 || len(m.Attachments) > 5 {
 		return
 	}
@@ -59,33 +94,39 @@ func handleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	var fs []*discordgo.MessageEmbedField
 	var mu sync.Mutex
 	var wg sync.WaitGroup
+	reacted := false
 
 	for at := range ch {
 		wg.Add(1)
 		go func(at *discordgo.MessageAttachment) {
 			defer wg.Done()
 
-			logger.Info("Downloading attachment", "url", at.URL)
-			resp, err := http.Get(at.URL)
-			if err != nil {
-				logger.Error("Failed to download file", "error", err, "url", at.URL)
+			if ok, retryAfter := rl.Allow(ratelimit.Key(m.GuildID, m.ChannelID)); !ok {
+				logger.Info("Rate limited, dropping attachment", "url", at.URL, "retry_after", retryAfter)
+				mu.Lock()
+				if !reacted {
+					reacted = true
+					if err := s.MessageReactionAdd(m.ChannelID, m.ID, "⏱️"); err != nil {
+						logger.Error("Error adding rate limit reaction", "error", err)
+					}
+				}
+				mu.Unlock()
 				return
 			}
-			defer resp.Body.Close()
 
-			logger.Info("Reading content", "url", at.URL)
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				logger.Error("Failed to read file content", "error", err, "url", at.URL)
+			logger.Info("Downloading attachment", "url", at.URL)
+			body, err := fetchLogContent(ctx, at.URL, maxLogSize)
+			if errors.Is(err, errLogTooLarge) {
+				logger.Info("File too large, skipping", "url", at.URL)
 				return
-			}
-
-			if len(body) > 10*1024*1024 /* 10MiB */ {
-				logger.Info("File too large, skipping", "size", len(body), "url", at.URL)
+			} else if err != nil {
+				logger.Error("Failed to download file", "error", err, "url", at.URL)
 				return
 			}
 
-			pr, err := mclc.PasteLog(string(body))
+			redacted, redactions := redact.Redact(body, redactMode)
+
+			pr, err := mclc.PasteLog(ctx, redacted)
 			if err != nil {
 				logger.Error("Failed to paste log", "error", err, "url", at.URL)
 				return
@@ -93,19 +134,16 @@ func handleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 			logger.Info("Pasted log successfully", "response", pr)
 
-			an, err := mclc.GetInsights(pr.ID)
+			an, err := mclc.GetInsights(ctx, pr.ID)
 			if err != nil {
 				logger.Error("Failed to get paste insights", "error", err, "id", pr.ID)
+				return
 			}
 
 			logger.Info("Retrieved log insights successfully", "response", an)
 
 			mu.Lock()
-			fs = append(fs, &discordgo.MessageEmbedField{
-				Name:   an.Title,
-				Value:  pr.URL,
-				Inline: true,
-			})
+			fs = append(fs, insightFields(an, pr.URL, redactions)...)
 			mu.Unlock()
 		}(at)
 	}
@@ -119,26 +157,7 @@ func handleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 	logger.Info(fmt.Sprintf("Uploaded %v files.", len(fs)), "pastes", fs)
 
-	re := &discordgo.MessageEmbed{
-		Author: &discordgo.MessageEmbedAuthor{
-			Name: "mclo.gs",
-			URL:  "https://mclo.gs/",
-		},
-		Title:       "Your logs were uploaded for easier reading",
-		Description: "-# [Why?](https://github.com/EmilyxFox/go-mclogs-bot/blob/main/why.md) [Source](https://github.com/emilyxfox/go-mclogs-bot)",
-		Fields:      fs,
-		Color:       0x2d3943,
-		Timestamp:   time.Now().Format(time.RFC3339),
-	}
-
-	botUser, err := s.User("@me")
-	if err != nil {
-		logger.Error("Error fetching bot user", "error", err)
-	} else {
-		re.Author.IconURL = botUser.AvatarURL("32")
-	}
-
-	_, err = s.ChannelMessageSendEmbed(m.ChannelID, re)
+	_, err := s.ChannelMessageSendEmbeds(m.ChannelID, logsEmbeds(s, logger, uploadedEmbedTitle, fs))
 	if err != nil {
 		logger.Error("Failed to send message to Discord", "error", err)
 	}
@@ -163,7 +182,15 @@ func main() {
 
 	discord.Identify.Intents += discordgo.IntentMessageContent
 
-	discord.AddHandler(handleMessageCreate)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	discord.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		handleMessageCreate(ctx, s, m)
+	})
+	discord.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		handleInteractionCreate(ctx, s, i)
+	})
 
 	err = discord.Open()
 	if err != nil {
@@ -177,10 +204,18 @@ func main() {
 	}
 	slog.Info(fmt.Sprintf("Logged in as %v#%v", botUser.Username, botUser.Discriminator))
 
+	for _, cmd := range commands {
+		if _, err := discord.ApplicationCommandCreate(botUser.ID, "", cmd); err != nil {
+			slog.Error("Error registering command", "error", err, "command", cmd.Name)
+		}
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
 
 	slog.Info("Shutting down...")
+	cancel()
 	discord.Close()
+	rl.Close()
 }
@@ -0,0 +1,191 @@
+// Package local implements a regex-rule log analyzer that produces
+// mclogs.InsightsResponse-shaped results without contacting the mclo.gs API.
+// It is a fallback for when the API is down, when a user opts out of
+// uploading their log, or when the log exceeds mclo.gs's size cap.
+package local
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/emilyxfox/go-mclogs-bot/mclogs"
+)
+
+//go:embed rules/*.yaml
+var defaultRules embed.FS
+
+// Rule describes a single pattern to match against a log, line by line. A
+// rule with a non-empty Label produces an Information entry, capturing the
+// pattern's first submatch group as the value. A rule with an empty Label
+// produces a Problem entry using Message and Solutions.
+type Rule struct {
+	Pattern   string   `yaml:"pattern"`
+	Message   string   `yaml:"message"`
+	Solutions []string `yaml:"solutions"`
+	Label     string   `yaml:"label"`
+}
+
+// RulePack is a named collection of rules, loaded from a single YAML (or
+// JSON, which is valid YAML) file.
+type RulePack struct {
+	Version string `yaml:"version"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Analyzer matches log content against a set of loaded rules.
+type Analyzer struct {
+	rules []compiledRule
+}
+
+// New builds an Analyzer from the embedded default rule pack, plus any
+// additional *.yaml, *.yml or *.json rule packs found in dir. dir may be
+// empty, in which case only the defaults are loaded.
+func New(dir string) (*Analyzer, error) {
+	a := &Analyzer{}
+
+	if err := a.loadFS(defaultRules, "rules"); err != nil {
+		return nil, fmt.Errorf("loading default rules: %w", err)
+	}
+
+	if dir != "" {
+		if err := a.loadDir(dir); err != nil {
+			return nil, fmt.Errorf("loading rules from %s: %w", dir, err)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *Analyzer) loadFS(fsys fs.FS, root string) error {
+	entries, err := fs.Glob(fsys, root+"/*.yaml")
+	if err != nil {
+		return err
+	}
+	for _, name := range entries {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := a.loadRulePack(b, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Analyzer) loadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := a.loadRulePack(b, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Analyzer) loadRulePack(b []byte, source string) error {
+	var pack RulePack
+	if err := yaml.Unmarshal(b, &pack); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	for _, r := range pack.Rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: rule %q: %w", source, r.Pattern, err)
+		}
+		a.rules = append(a.rules, compiledRule{Rule: r, re: re})
+	}
+	return nil
+}
+
+// Analyse matches content against the loaded rules and returns the result in
+// the same shape GetInsights/AnalyseLog return, so it's a drop-in substitute
+// for Client.AnalyseLog.
+func (a *Analyzer) Analyse(content string) *mclogs.InsightsResponse {
+	lines := strings.Split(content, "\n")
+
+	var information []mclogs.Information
+	var problemOrder []string
+	problems := make(map[string]*mclogs.Problem)
+
+	for lineNo, line := range lines {
+		for _, r := range a.rules {
+			m := r.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+
+			entry := mclogs.LogEntry{
+				Lines: []mclogs.LogLine{{Number: lineNo + 1, Content: line}},
+			}
+
+			if r.Label != "" {
+				value := m[0]
+				if len(m) > 1 {
+					value = m[1]
+				}
+				information = append(information, mclogs.Information{
+					Label: r.Label,
+					Value: value,
+					Entry: entry,
+				})
+				continue
+			}
+
+			p, ok := problems[r.Message]
+			if !ok {
+				p = &mclogs.Problem{Message: r.Message, Entry: entry}
+				for _, sol := range r.Solutions {
+					p.Solutions = append(p.Solutions, mclogs.Solution{Message: sol})
+				}
+				problems[r.Message] = p
+				problemOrder = append(problemOrder, r.Message)
+			}
+			p.Counter++
+		}
+	}
+
+	orderedProblems := make([]mclogs.Problem, 0, len(problemOrder))
+	for _, msg := range problemOrder {
+		orderedProblems = append(orderedProblems, *problems[msg])
+	}
+
+	return &mclogs.InsightsResponse{
+		Title: "Local analysis",
+		Analysis: mclogs.Analysis{
+			Problems:    orderedProblems,
+			Information: information,
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package mclogs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -17,6 +18,15 @@ type Client struct {
 	BaseURL string
 	// HTTPClient is used to make requests.
 	HTTPClient *http.Client
+
+	// MaxRetries caps how many additional attempts are made after a
+	// retryable (429/5xx) response, not counting the first attempt.
+	MaxRetries int
+	// MaxElapsed caps the total time spent retrying a single request.
+	MaxElapsed time.Duration
+	// Backoff computes how long to wait before retry attempt n (1-indexed).
+	// Skipped in favour of a response's Retry-After header when present.
+	Backoff func(attempt int) time.Duration
 }
 
 // NewClient creates a new mclo.gs API client.
@@ -24,9 +34,17 @@ func NewClient() *Client {
 	return &Client{
 		BaseURL:    "https://api.mclo.gs",
 		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		MaxRetries: 5,
+		MaxElapsed: 30 * time.Second,
+		Backoff:    DefaultBackoff,
 	}
 }
 
+// PasteURL returns the public mclo.gs URL for the paste with the given id.
+func PasteURL(id string) string {
+	return "https://mclo.gs/" + id
+}
+
 // PasteResponse represents the response from uploading a log.
 type PasteResponse struct {
 	Success bool   `json:"success"`
@@ -99,18 +117,20 @@ type Limits struct {
 }
 
 // PasteLog uploads the given log content to mclo.gs and returns the PasteResponse.
-func (c *Client) PasteLog(content string) (*PasteResponse, error) {
+func (c *Client) PasteLog(ctx context.Context, content string) (*PasteResponse, error) {
 	endpoint := c.BaseURL + "/1/log"
-	data := url.Values{}
-	data.Set("content", content)
 
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		data := url.Values{}
+		data.Set("content", content)
 
-	resp, err := c.HTTPClient.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -127,14 +147,12 @@ func (c *Client) PasteLog(content string) (*PasteResponse, error) {
 }
 
 // GetRawLog retrieves the raw log content by its id.
-func (c *Client) GetRawLog(id string) (string, error) {
+func (c *Client) GetRawLog(ctx context.Context, id string) (string, error) {
 	endpoint := c.BaseURL + "/1/raw/" + id
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return "", err
-	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -161,14 +179,12 @@ func (c *Client) GetRawLog(id string) (string, error) {
 }
 
 // GetInsights retrieves parsed insights for the log with the given id.
-func (c *Client) GetInsights(id string) (*InsightsResponse, error) {
+func (c *Client) GetInsights(ctx context.Context, id string) (*InsightsResponse, error) {
 	endpoint := c.BaseURL + "/1/insights/" + id
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -198,18 +214,20 @@ func (c *Client) GetInsights(id string) (*InsightsResponse, error) {
 }
 
 // AnalyseLog analyses the provided log content without saving it and returns the insights.
-func (c *Client) AnalyseLog(content string) (*InsightsResponse, error) {
+func (c *Client) AnalyseLog(ctx context.Context, content string) (*InsightsResponse, error) {
 	endpoint := c.BaseURL + "/1/analyse"
-	data := url.Values{}
-	data.Set("content", content)
 
-	req, err := http.NewRequest("POST", endpoint, strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		data := url.Values{}
+		data.Set("content", content)
 
-	resp, err := c.HTTPClient.Do(req)
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -238,14 +256,12 @@ func (c *Client) AnalyseLog(content string) (*InsightsResponse, error) {
 }
 
 // CheckLimits retrieves the current storage limits for logs.
-func (c *Client) CheckLimits() (*Limits, error) {
+func (c *Client) CheckLimits(ctx context.Context) (*Limits, error) {
 	endpoint := c.BaseURL + "/1/limits"
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,98 @@
+// Package redact scrubs likely secrets out of log content before it's
+// uploaded to mclo.gs, since pastes are public URLs.
+package redact
+
+import "regexp"
+
+// Mode controls how aggressively Redact scrubs content.
+type Mode int
+
+const (
+	// Off disables redaction entirely.
+	Off Mode = iota
+	// Default redacts auth tokens, obvious env-var leaks, IP addresses, and
+	// filesystem user paths — everything a public mclo.gs paste shouldn't
+	// carry.
+	Default
+	// Strict is currently equivalent to Default; reserved for rules too
+	// aggressive (i.e. too prone to false positives) to enable by default.
+	Strict
+)
+
+// ParseMode parses the MCLOGS_REDACT env var value. An empty or unrecognised
+// value is treated as Default, so redaction is on unless explicitly disabled.
+func ParseMode(s string) Mode {
+	switch s {
+	case "off":
+		return Off
+	case "strict":
+		return Strict
+	default:
+		return Default
+	}
+}
+
+// rule replaces every match of re with replace (which may reference capture
+// groups, e.g. "$1=<TOKEN>"). strict rules only run in Strict mode.
+type rule struct {
+	label   string
+	re      *regexp.Regexp
+	replace string
+	strict  bool
+}
+
+var rules = []rule{
+	{
+		// Mojang/Microsoft auth tokens are JWT-shaped.
+		label:   "token",
+		re:      regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*\b`),
+		replace: "<TOKEN>",
+	},
+	{
+		label:   "token",
+		re:      regexp.MustCompile(`(?i)\b((?:DISCORD|MOJANG|MICROSOFT)_TOKEN|[A-Z_]*(?:SECRET|API_KEY|PASSWORD))\s*=\s*\S+`),
+		replace: "$1=<TOKEN>",
+	},
+	{
+		label:   "user path",
+		re:      regexp.MustCompile(`/home/[^/\s]+`),
+		replace: "/home/<USER>",
+	},
+	{
+		label:   "user path",
+		re:      regexp.MustCompile(`C:\\Users\\[^\\\s]+`),
+		replace: `C:\Users\<USER>`,
+	},
+	{
+		label:   "IP",
+		re:      regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+		replace: "<IP>",
+	},
+	{
+		label:   "IP",
+		re:      regexp.MustCompile(`\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b`),
+		replace: "<IP>",
+	},
+}
+
+// Redact scrubs s according to mode, replacing matches with stable
+// placeholders. It returns the scrubbed content and a count of matches per
+// rule label (e.g. {"IP": 3, "user path": 1}), so callers can show a
+// transparency note.
+func Redact(s string, mode Mode) (string, map[string]int) {
+	if mode == Off {
+		return s, nil
+	}
+
+	counts := make(map[string]int)
+	for _, r := range rules {
+		if r.strict && mode != Strict {
+			continue
+		}
+		if n := len(r.re.FindAllString(s, -1)); n > 0 {
+			counts[r.label] += n
+			s = r.re.ReplaceAllString(s, r.replace)
+		}
+	}
+	return s, counts
+}
@@ -0,0 +1,85 @@
+package mclogs
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultBackoff is an exponential backoff with full jitter: attempt 1 waits
+// up to ~200ms, doubling each attempt, capped at 5s.
+func DefaultBackoff(attempt int) time.Duration {
+	const (
+		base       = 200 * time.Millisecond
+		maxBackoff = 5 * time.Second
+	)
+
+	d := base * time.Duration(1<<uint(attempt-1))
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// newRequest builds an *http.Request fresh for every attempt, so a request
+// body can be re-read on retry.
+type newRequest func() (*http.Request, error)
+
+// doWithRetry executes the request built by newReq, retrying on 429 and 5xx
+// responses (honouring Retry-After when present) until c.MaxRetries or
+// c.MaxElapsed is exceeded. On success, or once c.MaxRetries is exhausted, it
+// returns the last response with its body unread and unclosed. If the next
+// wait would run past c.MaxElapsed, it closes the response and returns an
+// error instead.
+func (c *Client) doWithRetry(ctx context.Context, newReq newRequest) (*http.Response, error) {
+	deadline := time.Now().Add(c.MaxElapsed)
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isRetryableStatus(resp.StatusCode) || attempt >= c.MaxRetries {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp.Header)
+		if wait <= 0 {
+			wait = c.Backoff(attempt + 1)
+		}
+		resp.Body.Close()
+
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("mclogs: gave up after %d attempt(s): still receiving %d", attempt+1, resp.StatusCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given in seconds. It returns 0 if
+// the header is absent or not a plain integer.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
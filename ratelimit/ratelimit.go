@@ -0,0 +1,160 @@
+// Package ratelimit implements a per-key token-bucket rate limiter with a
+// shared global ceiling, mirroring the per-route + global scheme Discord
+// itself uses for its own API.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultGCInterval is how often idle buckets are swept from the map.
+const defaultGCInterval = time.Minute
+
+// defaultIdleTTL is how long a per-key bucket may go unused before it is
+// garbage collected.
+const defaultIdleTTL = 10 * time.Minute
+
+// bucket is a single token bucket: capacity tokens refilled at refillRate
+// tokens per second.
+type bucket struct {
+	mu sync.Mutex
+
+	capacity   float64
+	refillRate float64
+	tokens     float64
+	lastRefill time.Time
+	lastUsed   time.Time
+}
+
+func newBucket(capacity, refillRate float64) *bucket {
+	now := time.Now()
+	return &bucket{
+		capacity:   capacity,
+		refillRate: refillRate,
+		tokens:     capacity,
+		lastRefill: now,
+		lastUsed:   now,
+	}
+}
+
+// allow refills the bucket for elapsed time, then attempts to take a token.
+func (b *bucket) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(b.lastRefill).Seconds(); elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.refillRate)
+		b.lastRefill = now
+	}
+	b.lastUsed = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / b.refillRate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+func (b *bucket) idleFor(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Sub(b.lastUsed)
+}
+
+// Limiter enforces a token bucket per key, plus a single global bucket that
+// every key shares, so no individual key can starve the rest.
+type Limiter struct {
+	capacity   float64
+	refillRate float64
+	global     *bucket
+
+	mu      sync.RWMutex
+	buckets map[string]*bucket
+
+	gcInterval time.Duration
+	idleTTL    time.Duration
+	stop       chan struct{}
+}
+
+// NewLimiter creates a Limiter whose per-key buckets allow keyRPS requests
+// per second (with a burst of the same size), alongside a global bucket
+// capped at globalRPS.
+func NewLimiter(keyRPS, globalRPS float64) *Limiter {
+	l := &Limiter{
+		capacity:   keyRPS,
+		refillRate: keyRPS,
+		global:     newBucket(globalRPS, globalRPS),
+		buckets:    make(map[string]*bucket),
+		gcInterval: defaultGCInterval,
+		idleTTL:    defaultIdleTTL,
+		stop:       make(chan struct{}),
+	}
+	go l.gcLoop()
+	return l
+}
+
+// Key joins a guild and channel ID into the key Allow expects.
+func Key(guildID, channelID string) string {
+	return guildID + ":" + channelID
+}
+
+// Allow reports whether a request for key may proceed, consuming a token
+// from both its own bucket and the global bucket. If denied, retryAfter is
+// the time until a token becomes available on whichever bucket was
+// exhausted.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	if ok, retryAfter := l.bucketFor(key).allow(); !ok {
+		return false, retryAfter
+	}
+	return l.global.allow()
+}
+
+// Close stops the background GC goroutine.
+func (l *Limiter) Close() {
+	close(l.stop)
+}
+
+func (l *Limiter) bucketFor(key string) *bucket {
+	l.mu.RLock()
+	b, ok := l.buckets[key]
+	l.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+	b = newBucket(l.capacity, l.refillRate)
+	l.buckets[key] = b
+	return b
+}
+
+func (l *Limiter) gcLoop() {
+	ticker := time.NewTicker(l.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.gc()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *Limiter) gc() {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, b := range l.buckets {
+		if b.idleFor(now) > l.idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}